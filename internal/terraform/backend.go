@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// errWriteBackend is returned when a Backend's generated configuration
+// cannot be written to the Harness's Dir.
+const errWriteBackend = "cannot write backend configuration"
+
+// backendFilename is the name of the file Init writes into a Harness's Dir
+// when configured via WithBackend. It is loaded by Terraform alongside the
+// rest of the module's configuration files.
+const backendFilename = "crossplane-provider-terraform-backend.tf"
+
+// A Backend generates the Terraform configuration required to configure a
+// particular remote state backend.
+type Backend interface {
+	// HCL returns the content of a Terraform configuration file that
+	// configures the backend, for example a 'terraform { backend "s3" {} }'
+	// block.
+	HCL() ([]byte, error)
+}
+
+// WithBackend causes Init to write the supplied Backend's configuration into
+// a file in Dir once Dir is no longer empty, then run 'terraform init
+// -reconfigure' so that Terraform adopts it even if Dir was previously
+// initialized with a different backend (e.g. the default local backend).
+// Without a Backend, Terraform state is stored on disk in Dir and is lost
+// once Dir is removed.
+func WithBackend(b Backend) Option {
+	return func(h *Harness) ([]string, error) {
+		h.backend = b
+		return nil, nil
+	}
+}
+
+// backendBlock renders a 'terraform { backend "<name>" { ... } }' block from
+// an ordered list of attributes, skipping any whose value is empty so that
+// optional fields may be left unset.
+func backendBlock(name string, attrs [][2]string) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "terraform {\n  backend %q {\n", name)
+	for _, a := range attrs {
+		if a[1] == "" {
+			continue
+		}
+		fmt.Fprintf(buf, "    %s = %q\n", a[0], a[1])
+	}
+	buf.WriteString("  }\n}\n")
+	return buf.Bytes()
+}
+
+// An S3Backend stores Terraform state in an AWS S3 bucket, optionally using
+// DynamoDB for state locking.
+type S3Backend struct {
+	Bucket             string
+	Key                string
+	Region             string
+	WorkspaceKeyPrefix string
+	DynamoDBTable      string
+	RoleARN            string
+	Profile            string
+}
+
+// HCL returns the Terraform configuration for this S3Backend.
+func (b S3Backend) HCL() ([]byte, error) {
+	return backendBlock("s3", [][2]string{
+		{"bucket", b.Bucket},
+		{"key", b.Key},
+		{"region", b.Region},
+		{"workspace_key_prefix", b.WorkspaceKeyPrefix},
+		{"dynamodb_table", b.DynamoDBTable},
+		{"role_arn", b.RoleARN},
+		{"profile", b.Profile},
+	}), nil
+}
+
+// A GCSBackend stores Terraform state in a Google Cloud Storage bucket.
+type GCSBackend struct {
+	Bucket      string
+	Prefix      string
+	Credentials string
+}
+
+// HCL returns the Terraform configuration for this GCSBackend.
+func (b GCSBackend) HCL() ([]byte, error) {
+	return backendBlock("gcs", [][2]string{
+		{"bucket", b.Bucket},
+		{"prefix", b.Prefix},
+		{"credentials", b.Credentials},
+	}), nil
+}
+
+// An AzurermBackend stores Terraform state in an Azure Storage container.
+type AzurermBackend struct {
+	StorageAccountName string
+	ContainerName      string
+	Key                string
+	ResourceGroupName  string
+}
+
+// HCL returns the Terraform configuration for this AzurermBackend.
+func (b AzurermBackend) HCL() ([]byte, error) {
+	return backendBlock("azurerm", [][2]string{
+		{"storage_account_name", b.StorageAccountName},
+		{"container_name", b.ContainerName},
+		{"key", b.Key},
+		{"resource_group_name", b.ResourceGroupName},
+	}), nil
+}
+
+// A KubernetesBackend stores Terraform state in a Kubernetes Secret.
+type KubernetesBackend struct {
+	SecretSuffix string
+	Namespace    string
+	ConfigPath   string
+}
+
+// HCL returns the Terraform configuration for this KubernetesBackend.
+func (b KubernetesBackend) HCL() ([]byte, error) {
+	return backendBlock("kubernetes", [][2]string{
+		{"secret_suffix", b.SecretSuffix},
+		{"namespace", b.Namespace},
+		{"config_path", b.ConfigPath},
+	}), nil
+}
+
+// A LocalBackend stores Terraform state in a file on disk at Path. Unlike
+// every other Backend it requires no credentials or network access, which
+// makes it useful for testing WithBackend without a real remote backend.
+type LocalBackend struct {
+	Path string
+}
+
+// HCL returns the Terraform configuration for this LocalBackend.
+func (b LocalBackend) HCL() ([]byte, error) {
+	return backendBlock("local", [][2]string{{"path", b.Path}}), nil
+}
+
+// A RemoteBackend stores Terraform state in Terraform Cloud or Terraform
+// Enterprise.
+type RemoteBackend struct {
+	Hostname     string
+	Organization string
+
+	// WorkspaceName names a single workspace, and WorkspacePrefix is a prefix
+	// shared by many workspaces. Exactly one should be set.
+	WorkspaceName   string
+	WorkspacePrefix string
+}
+
+// HCL returns the Terraform configuration for this RemoteBackend.
+func (b RemoteBackend) HCL() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "terraform {\n  backend \"remote\" {\n")
+	if b.Hostname != "" {
+		fmt.Fprintf(buf, "    hostname = %q\n", b.Hostname)
+	}
+	fmt.Fprintf(buf, "    organization = %q\n", b.Organization)
+	buf.WriteString("    workspaces {\n")
+	if b.WorkspaceName != "" {
+		fmt.Fprintf(buf, "      name = %q\n", b.WorkspaceName)
+	}
+	if b.WorkspacePrefix != "" {
+		fmt.Fprintf(buf, "      prefix = %q\n", b.WorkspacePrefix)
+	}
+	buf.WriteString("    }\n  }\n}\n")
+	return buf.Bytes(), nil
+}
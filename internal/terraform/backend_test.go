@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBackendHCL(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		b      Backend
+		want   []string
+	}{
+		"S3": {
+			reason: "An S3Backend should render a backend \"s3\" block with its non-empty fields.",
+			b: S3Backend{
+				Bucket: "cool-bucket",
+				Key:    "terraform.tfstate",
+				Region: "us-east-1",
+			},
+			want: []string{
+				`backend "s3"`,
+				`bucket = "cool-bucket"`,
+				`key = "terraform.tfstate"`,
+				`region = "us-east-1"`,
+			},
+		},
+		"GCS": {
+			reason: "A GCSBackend should render a backend \"gcs\" block with its non-empty fields.",
+			b: GCSBackend{
+				Bucket: "cool-bucket",
+				Prefix: "terraform/state",
+			},
+			want: []string{
+				`backend "gcs"`,
+				`bucket = "cool-bucket"`,
+				`prefix = "terraform/state"`,
+			},
+		},
+		"Azurerm": {
+			reason: "An AzurermBackend should render a backend \"azurerm\" block with its non-empty fields.",
+			b: AzurermBackend{
+				StorageAccountName: "coolstorageaccount",
+				ContainerName:      "tfstate",
+				Key:                "terraform.tfstate",
+			},
+			want: []string{
+				`backend "azurerm"`,
+				`storage_account_name = "coolstorageaccount"`,
+				`container_name = "tfstate"`,
+			},
+		},
+		"Kubernetes": {
+			reason: "A KubernetesBackend should render a backend \"kubernetes\" block with its non-empty fields.",
+			b: KubernetesBackend{
+				SecretSuffix: "cool-state",
+				Namespace:    "crossplane-system",
+			},
+			want: []string{
+				`backend "kubernetes"`,
+				`secret_suffix = "cool-state"`,
+				`namespace = "crossplane-system"`,
+			},
+		},
+		"Remote": {
+			reason: "A RemoteBackend should render a backend \"remote\" block with a nested workspaces block.",
+			b: RemoteBackend{
+				Organization:  "coolorg",
+				WorkspaceName: "cool-workspace",
+			},
+			want: []string{
+				`backend "remote"`,
+				`organization = "coolorg"`,
+				`workspaces {`,
+				`name = "cool-workspace"`,
+			},
+		},
+		"Local": {
+			reason: "A LocalBackend should render a backend \"local\" block with its path.",
+			b:      LocalBackend{Path: "/tmp/cool.tfstate"},
+			want: []string{
+				`backend "local"`,
+				`path = "/tmp/cool.tfstate"`,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.b.HCL()
+			if err != nil {
+				t.Fatalf("\n%s\ntc.b.HCL(): %v", tc.reason, err)
+			}
+
+			for _, want := range tc.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("\n%s\ntc.b.HCL(): %q does not contain %q\ngot:\n%s", tc.reason, name, want, got)
+				}
+			}
+		})
+	}
+}
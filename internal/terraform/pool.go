@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+	"sync"
+)
+
+// A Pool manages a single Terraform module directory (the Dir of its
+// underlying Harness) that is shared, via Terraform workspaces, by many
+// concurrent reconciles of different managed resources. Rather than each
+// managed resource re-initializing its own ephemeral Dir, a Pool's Dir is
+// initialized once and reused: every managed resource is given its own
+// workspace.
+//
+// Workspace selection is done via the TF_WORKSPACE environment variable
+// rather than 'terraform workspace select', which rewrites a file shared by
+// every goroutine using the same Dir and is therefore not safe to call
+// concurrently for different workspaces. Apply and Destroy calls made
+// against the same workspace are still serialized with a sync.Mutex, since
+// Terraform does not support concurrent state modification even within a
+// single workspace.
+type Pool struct {
+	h Harness
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewPool returns a Pool backed by the supplied Harness. The Harness's Dir
+// must already be initialized (see Harness.Init) before the Pool is used.
+func NewPool(h Harness) *Pool {
+	return &Pool{h: h, locks: make(map[string]*sync.Mutex)}
+}
+
+// Workspace returns a *Harness bound to the named Terraform workspace,
+// creating the workspace first if it does not already exist. It is safe to
+// call Workspace concurrently for different names. Apply and Destroy calls
+// made via Harnesses returned for the same name are serialized with respect
+// to one another; calls made for different names are not.
+func (p *Pool) Workspace(ctx context.Context, name string) (*Harness, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.h.Workspace(ctx, name); err != nil {
+		return nil, err
+	}
+
+	l, ok := p.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		p.locks[name] = l
+	}
+
+	h := p.h
+	h.Envs = append(append([]string{}, h.Envs...), "TF_WORKSPACE="+name)
+	h.workspaceLock = l
+	return &h, nil
+}
+
+// Workspaces returns the name of every Terraform workspace known to the
+// Pool's underlying Dir.
+func (p *Pool) Workspaces(ctx context.Context) ([]string, error) {
+	return p.h.Workspaces(ctx)
+}
+
+// DeleteWorkspace deletes the named Terraform workspace and forgets its
+// lock, if any. It is the caller's responsibility to ensure nothing else is
+// concurrently using the workspace's Harness.
+func (p *Pool) DeleteWorkspace(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Terraform refuses to delete the currently selected workspace, and
+	// Dir's shared selection (the '.terraform/environment' file) may still
+	// point at name from a prior Workspace call. Select default first so
+	// that name is never the active workspace when we delete it.
+	if name != defaultWorkspace {
+		if err := p.h.Workspace(ctx, defaultWorkspace); err != nil {
+			return err
+		}
+	}
+
+	if err := p.h.DeleteWorkspace(ctx, name); err != nil {
+		return err
+	}
+	delete(p.locks, name)
+	return nil
+}
@@ -0,0 +1,111 @@
+// +build invoke_terraform
+
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestPoolWorkspaceIsolation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider-terraform-test")
+	if err != nil {
+		t.Fatalf("Cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := Harness{Path: tfBinaryPath, Dir: dir}
+	if err := base.Init(context.Background(), filepath.Join(tfTestDataPath(), "nullmodule")); err != nil {
+		t.Fatalf("base.Init(...): %v", err)
+	}
+
+	p := NewPool(base)
+
+	const n = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("cool-%d", i)
+			value := fmt.Sprintf("extreme-%d", i)
+
+			tf, err := p.Workspace(context.Background(), name)
+			if err != nil {
+				errs <- errors.Wrapf(err, "p.Workspace(%q)", name)
+				return
+			}
+
+			// Plan via a var file, and apply the resulting plan, rather than
+			// just applying WithVar directly. Plan and WithVarFile both write
+			// a file into the Pool's shared Dir; running many of these
+			// concurrently across workspaces is what would catch them
+			// clobbering one another's file.
+			varfile := []byte(fmt.Sprintf("coolness = %q\n", value))
+			plan, err := tf.Plan(context.Background(), WithVarFile(varfile, HCL))
+			if err != nil {
+				errs <- errors.Wrapf(err, "tf.Plan(%q)", name)
+				return
+			}
+
+			if err := tf.ApplyPlan(context.Background(), plan); err != nil {
+				errs <- errors.Wrapf(err, "tf.ApplyPlan(%q)", name)
+				return
+			}
+
+			out, err := tf.Output(context.Background())
+			if err != nil {
+				errs <- errors.Wrapf(err, "tf.Output(%q)", name)
+				return
+			}
+
+			for _, o := range out {
+				if o.Name == "coolness" && o.StringValue() != value {
+					errs <- errors.Errorf("workspace %q: got coolness %q, want %q", name, o.StringValue(), value)
+				}
+			}
+
+			if err := tf.Destroy(context.Background(), WithVar("coolness", value)); err != nil {
+				errs <- errors.Wrapf(err, "tf.Destroy(%q)", name)
+				return
+			}
+
+			if err := p.DeleteWorkspace(context.Background(), name); err != nil {
+				errs <- errors.Wrapf(err, "p.DeleteWorkspace(%q)", name)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,791 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package terraform contains logic for interacting with Terraform.
+package terraform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Terraform CLI arguments.
+const (
+	noninteractive = "-input=false"
+	noColor        = "-no-color"
+)
+
+// filenameBase prefixes every file a Harness writes into its Dir. var files
+// and plan files are additionally suffixed with the active Terraform
+// workspace's name (see Harness.workspace), since a Pool's workspaces all
+// share the same Dir and would otherwise clobber one another's files.
+const filenameBase = "crossplane-provider-terraform"
+
+// Error strings.
+const (
+	errFmtInvalidConfig = "invalid configuration, %d error(s)"
+	errValidate         = "cannot validate module"
+	errInit             = "cannot initialize module"
+	errWorkspace        = "cannot select workspace"
+	errApply            = "cannot apply configuration"
+	errDestroy          = "cannot destroy configuration"
+	errOutput           = "cannot read outputs"
+	errWriteVarFile     = "cannot write tfvars file"
+	errPlan             = "cannot plan configuration"
+	errApplyPlan        = "cannot apply plan"
+	errWorkspaces       = "cannot list workspaces"
+	errDeleteWorkspace  = "cannot delete workspace"
+)
+
+// Terraform's detailed exit codes for 'plan -detailed-exitcode'. See
+// https://www.terraform.io/cli/commands/plan#detailed-exitcode for details.
+const (
+	exitCodeNoChanges = 0
+	exitCodeChanges   = 2
+)
+
+// VarFileFormat is the format (HCL or JSON) of a Terraform variables file.
+type VarFileFormat string
+
+// Supported VarFileFormats.
+const (
+	HCL  VarFileFormat = "hcl"
+	JSON VarFileFormat = "json"
+)
+
+// Option is used to configure an invocation of Terraform. Some options (for
+// example WithVarFile) need to write data into the Harness's directory before
+// Terraform is invoked, so each Option is given the Harness it will run
+// against and returns the CLI arguments it contributes.
+type Option func(h *Harness) ([]string, error)
+
+// WithVar supplies a Terraform variable via the '-var' CLI argument.
+func WithVar(k, v string) Option {
+	return func(h *Harness) ([]string, error) {
+		return []string{"-var", k + "=" + v}, nil
+	}
+}
+
+// WithVarFile supplies Terraform variables via a '-var-file' loaded from the
+// supplied data, which should be encoded per the supplied VarFileFormat.
+func WithVarFile(data []byte, format VarFileFormat) Option {
+	return func(h *Harness) ([]string, error) {
+		name := filenameBase + "-" + h.workspace() + ".tfvars"
+		if format == JSON {
+			name += ".json"
+		}
+		path := filepath.Join(h.Dir, name)
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return nil, errors.Wrap(err, errWriteVarFile)
+		}
+		return []string{"-var-file", path}, nil
+	}
+}
+
+// WithPluginCacheDir causes Init to use the supplied directory as Terraform's
+// shared plugin cache (via the TF_PLUGIN_CACHE_DIR environment variable), so
+// that providers already present in the cache are reused rather than
+// re-downloaded. This is equivalent to setting the Harness's PluginCacheDir
+// field directly.
+func WithPluginCacheDir(path string) Option {
+	return func(h *Harness) ([]string, error) {
+		h.PluginCacheDir = path
+		return nil, nil
+	}
+}
+
+// WithMessageHandler causes every Message parsed from Terraform's '-json'
+// output to be passed to fn as it is emitted. Diagnostics are otherwise only
+// returned, inside a *DiagnosticsError, when a command fails. This allows a
+// caller to surface progress (e.g. apply_start, change_summary) rather than
+// waiting for the command to finish.
+func WithMessageHandler(fn func(Message)) Option {
+	return func(h *Harness) ([]string, error) {
+		h.messageHandler = fn
+		return nil, nil
+	}
+}
+
+// A Harness for running Terraform. Each Harness is a thin wrapper around the
+// Terraform binary, rooted at a particular Dir.
+type Harness struct {
+	// Path to the Terraform binary.
+	Path string
+
+	// Dir in which to run the Terraform binary.
+	Dir string
+
+	// Envs to set when running the Terraform binary, in addition to this
+	// process's own environment.
+	Envs []string
+
+	// PluginCacheDir, if set, is used as Terraform's shared plugin cache. It
+	// allows providers downloaded by Init to be reused by every Harness that
+	// shares the same cache directory, rather than re-downloaded per Dir.
+	PluginCacheDir string
+
+	// messageHandler, if set via WithMessageHandler, is called with every
+	// Message parsed from Terraform's '-json' output.
+	messageHandler func(Message)
+
+	// backend, if set via WithBackend, is written into Dir by Init once Dir
+	// is no longer empty (e.g. once any '-from-module' seeding has
+	// completed).
+	backend Backend
+
+	// workspaceLock, if set (by a Pool), is held for the duration of Apply
+	// and Destroy so that concurrent reconciles of the same workspace cannot
+	// race one another.
+	workspaceLock *sync.Mutex
+}
+
+// lock acquires the Harness's workspaceLock, if any, and returns a function
+// that releases it. It is a no-op for a Harness that wasn't handed out by a
+// Pool.
+func (h Harness) lock() func() {
+	if h.workspaceLock == nil {
+		return func() {}
+	}
+	h.workspaceLock.Lock()
+	return h.workspaceLock.Unlock
+}
+
+// workspace returns the name of the Terraform workspace the Harness is
+// scoped to, as set (by a Pool) via the TF_WORKSPACE environment variable,
+// or defaultWorkspace if this Harness was not obtained from a Pool.
+func (h Harness) workspace() string {
+	for _, e := range h.Envs {
+		if name := strings.TrimPrefix(e, "TF_WORKSPACE="); name != e {
+			return name
+		}
+	}
+	return defaultWorkspace
+}
+
+func (h Harness) env() []string {
+	env := append(os.Environ(), h.Envs...)
+	if h.PluginCacheDir != "" {
+		env = append(env, "TF_PLUGIN_CACHE_DIR="+h.PluginCacheDir)
+	}
+	return env
+}
+
+func (h Harness) cmd(ctx context.Context, args ...string) *exec.Cmd {
+	c := exec.CommandContext(ctx, h.Path, args...)
+	c.Dir = h.Dir
+	c.Env = h.env()
+	return c
+}
+
+// configure applies the supplied Options to a copy of the Harness, returning
+// the configured Harness (which Options may have mutated, e.g. to set its
+// PluginCacheDir) along with any CLI arguments they contributed.
+func (h Harness) configure(ctx context.Context, o ...Option) (Harness, []string, error) {
+	args := make([]string, 0, len(o))
+	for _, fn := range o {
+		a, err := fn(&h)
+		if err != nil {
+			return h, nil, err
+		}
+		args = append(args, a...)
+	}
+	return h, args, nil
+}
+
+// A Range identifies a location within a Terraform configuration file that a
+// Diagnostic pertains to.
+type Range struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// A Diagnostic is a single warning or error emitted by Terraform, parsed from
+// its '-json' output.
+type Diagnostic struct {
+	Severity string
+	Summary  string
+	Detail   string
+	Address  string
+	Range    Range
+	Snippet  string
+}
+
+// Diagnostics is a set of Diagnostic, in the order Terraform emitted them.
+type Diagnostics []Diagnostic
+
+// A ResourceChange identifies a single resource Terraform is creating,
+// updating, destroying, or has found drifted, and the action being taken
+// against it.
+type ResourceChange struct {
+	// Resource is the address of the resource the change pertains to, e.g.
+	// "null_resource.example".
+	Resource string
+
+	// Action Terraform is taking against Resource, e.g. "create", "update",
+	// or "delete".
+	Action string
+}
+
+// A ChangeSummary tallies the resource changes a plan or apply has found,
+// parsed from a "change_summary" Message.
+type ChangeSummary struct {
+	Add       int
+	Change    int
+	Remove    int
+	Operation string
+}
+
+// A Message is a single structured message emitted by Terraform's '-json'
+// output, for example a Diagnostic or a progress update such as
+// "apply_start". WithMessageHandler can be used to observe every Message as
+// it is emitted, rather than only the Diagnostics returned when a command
+// fails.
+type Message struct {
+	// Type is the kind of message Terraform emitted, e.g. "diagnostic",
+	// "apply_start", "apply_complete", "change_summary", "resource_drift",
+	// or "planned_change".
+	Type string
+
+	// Diagnostic is set when Type is "diagnostic".
+	Diagnostic *Diagnostic
+
+	// Change is set when Type is "apply_start", "apply_complete",
+	// "planned_change", or "resource_drift".
+	Change *ResourceChange
+
+	// Summary is set when Type is "change_summary".
+	Summary *ChangeSummary
+}
+
+// A DiagnosticsError is returned by Harness methods when a Terraform command
+// invoked with '-json' fails and reports one or more diagnostics, and by
+// Validate when the configuration is invalid. It wraps the underlying error
+// (the exec error, or Validate's invalid configuration error) so existing
+// error handling (e.g. errors.Cause) keeps working, while also exposing the
+// Diagnostics Terraform reported.
+type DiagnosticsError struct {
+	cause       error
+	msg         string
+	Diagnostics Diagnostics
+}
+
+func (e *DiagnosticsError) Error() string {
+	return e.msg
+}
+
+// Cause returns the error DiagnosticsError wraps, per github.com/pkg/errors.
+func (e *DiagnosticsError) Cause() error {
+	return e.cause
+}
+
+// tfRange mirrors the 'range' object Terraform emits as part of a diagnostic.
+type tfRange struct {
+	Filename string `json:"filename"`
+	Start    struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"start"`
+}
+
+// tfDiagnostic mirrors a single diagnostic emitted by Terraform, whether as
+// part of a 'validate -json' document or a '-json' NDJSON message stream.
+type tfDiagnostic struct {
+	Severity string   `json:"severity"`
+	Summary  string   `json:"summary"`
+	Detail   string   `json:"detail"`
+	Address  string   `json:"address"`
+	Range    *tfRange `json:"range"`
+	Snippet  *struct {
+		Code string `json:"code"`
+	} `json:"snippet"`
+}
+
+func (d *tfDiagnostic) Diagnostic() Diagnostic {
+	out := Diagnostic{Severity: d.Severity, Summary: d.Summary, Detail: d.Detail, Address: d.Address}
+	if d.Range != nil {
+		out.Range = Range{Filename: d.Range.Filename, Line: d.Range.Start.Line, Column: d.Range.Start.Column}
+	}
+	if d.Snippet != nil {
+		out.Snippet = d.Snippet.Code
+	}
+	return out
+}
+
+// tfResourceChange mirrors the 'hook' or 'change' object Terraform emits
+// alongside apply_start, apply_complete, planned_change, and resource_drift
+// messages.
+type tfResourceChange struct {
+	Resource struct {
+		Addr string `json:"addr"`
+	} `json:"resource"`
+	Action string `json:"action"`
+}
+
+func (c *tfResourceChange) ResourceChange() ResourceChange {
+	return ResourceChange{Resource: c.Resource.Addr, Action: c.Action}
+}
+
+// tfChangeSummary mirrors the 'changes' object Terraform emits alongside a
+// change_summary message.
+type tfChangeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+// tfMessage mirrors a single line of the NDJSON message stream emitted by
+// Terraform when run with '-json'.
+type tfMessage struct {
+	Type       string            `json:"type"`
+	Diagnostic *tfDiagnostic     `json:"diagnostic"`
+	Hook       *tfResourceChange `json:"hook"`
+	Change     *tfResourceChange `json:"change"`
+	Changes    *tfChangeSummary  `json:"changes"`
+}
+
+// streamJSON starts the supplied command, which must have been built to run
+// Terraform with '-json', and streams its NDJSON output to any configured
+// message handler, collecting the Diagnostics it reports. It returns those
+// Diagnostics along with whatever error the command exited with, wrapped
+// with any captured stderr but not with an errFmt message, so that callers
+// who need to inspect the exit code (e.g. Plan's '-detailed-exitcode') can
+// recover it via errors.Cause.
+func (h Harness) streamJSON(cmd *exec.Cmd) (Diagnostics, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var diags Diagnostics
+	sc := bufio.NewScanner(stdout)
+	for sc.Scan() {
+		m := tfMessage{}
+		if json.Unmarshal(sc.Bytes(), &m) != nil {
+			// Not every line Terraform emits is a message we understand;
+			// ignore anything we can't parse.
+			continue
+		}
+
+		msg := Message{Type: m.Type}
+		if m.Diagnostic != nil {
+			d := m.Diagnostic.Diagnostic()
+			msg.Diagnostic = &d
+			diags = append(diags, d)
+		}
+		// apply_start and apply_complete carry their resource and action in
+		// 'hook'; planned_change and resource_drift carry the same shape in
+		// 'change'.
+		if m.Hook != nil {
+			c := m.Hook.ResourceChange()
+			msg.Change = &c
+		}
+		if m.Change != nil {
+			c := m.Change.ResourceChange()
+			msg.Change = &c
+		}
+		if m.Changes != nil {
+			msg.Summary = &ChangeSummary{
+				Add:       m.Changes.Add,
+				Change:    m.Changes.Change,
+				Remove:    m.Changes.Remove,
+				Operation: m.Changes.Operation,
+			}
+		}
+		if h.messageHandler != nil {
+			h.messageHandler(msg)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if stderr.Len() > 0 {
+			return diags, errors.Wrap(err, stderr.String())
+		}
+		return diags, err
+	}
+	return diags, nil
+}
+
+// runJSON runs Terraform with the supplied args, which must include '-json',
+// via streamJSON. If the command exits with an error, the returned error is
+// a *DiagnosticsError when at least one Diagnostic was parsed, and a plain
+// wrapped error otherwise.
+func (h Harness) runJSON(ctx context.Context, errFmt string, args ...string) (Diagnostics, error) {
+	diags, err := h.streamJSON(h.cmd(ctx, args...))
+	if err != nil {
+		if len(diags) > 0 {
+			return diags, &DiagnosticsError{cause: err, msg: errors.Wrap(err, errFmt).Error(), Diagnostics: diags}
+		}
+		return diags, errors.Wrap(err, errFmt)
+	}
+	return diags, nil
+}
+
+// Validate the Terraform configuration in the Harness's Dir.
+func (h Harness) Validate(ctx context.Context) error {
+	out, err := h.cmd(ctx, "validate", noColor, "-json").Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return errors.Wrap(err, errValidate)
+		}
+	}
+
+	v := &struct {
+		Valid       bool           `json:"valid"`
+		ErrorCount  int            `json:"error_count"`
+		Diagnostics []tfDiagnostic `json:"diagnostics"`
+	}{}
+	if jerr := json.Unmarshal(out, v); jerr != nil {
+		return errors.Wrap(jerr, errValidate)
+	}
+
+	diags := make(Diagnostics, 0, len(v.Diagnostics))
+	for i := range v.Diagnostics {
+		d := v.Diagnostics[i].Diagnostic()
+		diags = append(diags, d)
+		if h.messageHandler != nil {
+			h.messageHandler(Message{Type: "diagnostic", Diagnostic: &d})
+		}
+	}
+
+	if !v.Valid {
+		invalid := errors.Errorf(errFmtInvalidConfig, v.ErrorCount)
+		return &DiagnosticsError{cause: invalid, msg: invalid.Error(), Diagnostics: diags}
+	}
+	return nil
+}
+
+// Init initializes the Harness's Dir, optionally seeding it from fromModule
+// before doing so.
+func (h Harness) Init(ctx context.Context, fromModule string, o ...Option) error {
+	// Apply every Option first, since some (e.g. WithPluginCacheDir and
+	// WithMessageHandler) only mutate the Harness used to run Terraform and
+	// must take effect before the '-from-module' seed init below, not just
+	// the init that follows it. Options that instead need to write into Dir
+	// (e.g. WithBackend) stash what they need to write on the Harness rather
+	// than writing it immediately, since '-from-module' requires Dir to be
+	// empty.
+	h, extra, err := h.configure(ctx, o...)
+	if err != nil {
+		return errors.Wrap(err, errInit)
+	}
+
+	if fromModule != "" {
+		seed := []string{"init", noColor, noninteractive, "-json", "-from-module", fromModule}
+		if _, err := h.runJSON(ctx, errInit, seed...); err != nil {
+			return err
+		}
+	}
+
+	if h.backend != nil {
+		hcl, err := h.backend.HCL()
+		if err != nil {
+			return errors.Wrap(err, errWriteBackend)
+		}
+		path := filepath.Join(h.Dir, backendFilename)
+		if err := ioutil.WriteFile(path, hcl, 0600); err != nil {
+			return errors.Wrap(err, errWriteBackend)
+		}
+		extra = append(extra, "-reconfigure")
+	}
+
+	if fromModule != "" && len(extra) == 0 {
+		// We already seeded Dir from fromModule above, and nothing needs a
+		// second init.
+		return nil
+	}
+
+	args := append([]string{"init", noColor, noninteractive, "-json"}, extra...)
+
+	_, err = h.runJSON(ctx, errInit, args...)
+	return err
+}
+
+// defaultWorkspace is the name of the Terraform workspace that always
+// exists and can never be deleted.
+const defaultWorkspace = "default"
+
+// Workspace selects the named Terraform workspace, creating it if it does not
+// already exist.
+func (h Harness) Workspace(ctx context.Context, name string) error {
+	if err := h.cmd(ctx, "workspace", "select", noColor, name).Run(); err == nil {
+		return nil
+	}
+	if err := h.cmd(ctx, "workspace", "new", noColor, name).Run(); err != nil {
+		return errors.Wrap(err, errWorkspace)
+	}
+	return nil
+}
+
+// Apply the Terraform configuration in the Harness's Dir.
+func (h Harness) Apply(ctx context.Context, o ...Option) error {
+	defer h.lock()()
+
+	h, extra, err := h.configure(ctx, o...)
+	if err != nil {
+		return errors.Wrap(err, errApply)
+	}
+	args := append([]string{"apply", noColor, noninteractive, "-auto-approve", "-json"}, extra...)
+
+	_, err = h.runJSON(ctx, errApply, args...)
+	return err
+}
+
+// A PlanFile is a handle to a binary Terraform plan file previously produced
+// by Plan. It refers to a path relative to the Harness's Dir, so it is only
+// meaningful to a Harness rooted at the same Dir (or a copy thereof) that
+// produced it.
+type PlanFile struct {
+	path string
+
+	// PlanDiff is true if the plan contains changes to apply, and false if
+	// applying it would be a no-op. It is derived from Terraform's detailed
+	// exit code.
+	PlanDiff bool
+}
+
+// WithPlan causes Apply to apply the supplied, previously computed PlanFile
+// rather than planning again itself. This ensures that what is applied is
+// exactly what was planned.
+func WithPlan(p PlanFile) Option {
+	return func(h *Harness) ([]string, error) {
+		return []string{p.path}, nil
+	}
+}
+
+// WithTarget limits the scope of Plan, Apply, or Destroy to the resource at
+// the supplied address, via Terraform's '-target' flag. It may be supplied
+// more than once to target multiple resources.
+func WithTarget(addr string) Option {
+	return func(h *Harness) ([]string, error) {
+		return []string{"-target", addr}, nil
+	}
+}
+
+// WithReplace forces Plan or Apply to replace the resource at the supplied
+// address, via Terraform's '-replace' flag. It may be supplied more than
+// once to replace multiple resources.
+func WithReplace(addr string) Option {
+	return func(h *Harness) ([]string, error) {
+		return []string{"-replace", addr}, nil
+	}
+}
+
+// WithRefreshOnly causes Plan or Apply to update state to match real-world
+// infrastructure without proposing any other configuration changes, via
+// Terraform's '-refresh-only' flag.
+func WithRefreshOnly() Option {
+	return func(h *Harness) ([]string, error) {
+		return []string{"-refresh-only"}, nil
+	}
+}
+
+// Plan the Terraform configuration in the Harness's Dir, saving the result to
+// a plan file whose location is returned as a PlanFile. The PlanFile may
+// later be supplied to Apply via WithPlan to ensure what is applied exactly
+// matches what was planned.
+func (h Harness) Plan(ctx context.Context, o ...Option) (PlanFile, error) {
+	defer h.lock()()
+
+	h, extra, err := h.configure(ctx, o...)
+	if err != nil {
+		return PlanFile{}, errors.Wrap(err, errPlan)
+	}
+
+	// Name the plan file per-workspace so that a Pool's workspaces, which
+	// share a single Dir, cannot clobber one another's plan file.
+	// The path is relative to Dir, which Terraform is run in.
+	path := filenameBase + "-" + h.workspace() + ".tfplan"
+	args := append([]string{"plan", noColor, noninteractive, "-json", "-detailed-exitcode", "-out", path}, extra...)
+
+	diags, err := h.streamJSON(h.cmd(ctx, args...))
+	// streamJSON wraps cmd.Wait's error with captured stderr when there is
+	// any, which would otherwise hide the *exec.ExitError we need below in
+	// order to distinguish "changes present" from a real failure. Unwrap to
+	// the underlying cause rather than relying on the concrete type of err
+	// itself having survived.
+	switch e := errors.Cause(err).(type) {
+	case nil:
+		return PlanFile{path: path, PlanDiff: false}, nil
+	case *exec.ExitError:
+		if e.ExitCode() == exitCodeChanges {
+			return PlanFile{path: path, PlanDiff: true}, nil
+		}
+		if len(diags) > 0 {
+			return PlanFile{}, &DiagnosticsError{cause: err, msg: errors.Wrap(err, errPlan).Error(), Diagnostics: diags}
+		}
+		return PlanFile{}, errors.Wrap(err, errPlan)
+	default:
+		return PlanFile{}, errors.Wrap(err, errPlan)
+	}
+}
+
+// ApplyPlan applies a PlanFile previously produced by Plan. This is
+// equivalent to calling Apply with the WithPlan Option.
+func (h Harness) ApplyPlan(ctx context.Context, p PlanFile) error {
+	defer h.lock()()
+
+	_, err := h.runJSON(ctx, errApplyPlan, "apply", noColor, noninteractive, "-auto-approve", "-json", p.path)
+	return err
+}
+
+// Destroy the Terraform configuration in the Harness's Dir.
+func (h Harness) Destroy(ctx context.Context, o ...Option) error {
+	defer h.lock()()
+
+	h, extra, err := h.configure(ctx, o...)
+	if err != nil {
+		return errors.Wrap(err, errDestroy)
+	}
+	args := append([]string{"destroy", noColor, noninteractive, "-auto-approve", "-json"}, extra...)
+
+	_, err = h.runJSON(ctx, errDestroy, args...)
+	return err
+}
+
+// Workspaces returns the name of every Terraform workspace in the Harness's
+// Dir.
+func (h Harness) Workspaces(ctx context.Context) ([]string, error) {
+	out, err := h.cmd(ctx, "workspace", "list", noColor).Output()
+	if err != nil {
+		return nil, errors.Wrap(err, errWorkspaces)
+	}
+
+	names := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		// The currently selected workspace is prefixed with "* ".
+		name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if name == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// DeleteWorkspace deletes the named Terraform workspace.
+func (h Harness) DeleteWorkspace(ctx context.Context, name string) error {
+	if err := h.cmd(ctx, "workspace", "delete", noColor, name).Run(); err != nil {
+		return errors.Wrap(err, errDeleteWorkspace)
+	}
+	return nil
+}
+
+// An Output produced by a Terraform module.
+type Output struct {
+	Name      string
+	Sensitive bool
+	Type      string
+
+	value interface{}
+}
+
+// outputOutput mirrors a single entry in `terraform output -json`'s output.
+type outputOutput struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type"`
+	Value     interface{}     `json:"value"`
+}
+
+// StringValue returns the Output's value as a string.
+func (o Output) StringValue() string {
+	s, _ := o.value.(string)
+	return s
+}
+
+// NumberValue returns the Output's value as a string-encoded number.
+func (o Output) NumberValue() string {
+	switch v := o.value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// BoolValue returns the Output's value as a bool.
+func (o Output) BoolValue() bool {
+	b, _ := o.value.(bool)
+	return b
+}
+
+// JSONValue returns the Output's value marshalled as JSON.
+func (o Output) JSONValue() ([]byte, error) {
+	return json.Marshal(o.value)
+}
+
+// Output returns the outputs of the Terraform configuration in the Harness's
+// Dir.
+func (h Harness) Output(ctx context.Context) ([]Output, error) {
+	out, err := h.cmd(ctx, "output", noColor, "-json").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, errOutput)
+	}
+
+	raw := map[string]outputOutput{}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, errors.Wrap(err, errOutput)
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	outputs := make([]Output, 0, len(raw))
+	for _, name := range names {
+		o := raw[name]
+		var t string
+		// The 'type' field is either a bare string (e.g. "bool") or, for
+		// complex types, a JSON array (e.g. ["object", {...}]).
+		if err := json.Unmarshal(o.Type, &t); err != nil {
+			var complex []json.RawMessage
+			if jerr := json.Unmarshal(o.Type, &complex); jerr != nil || len(complex) == 0 {
+				return nil, errors.Wrap(err, errOutput)
+			}
+			if jerr := json.Unmarshal(complex[0], &t); jerr != nil {
+				return nil, errors.Wrap(jerr, errOutput)
+			}
+		}
+		outputs = append(outputs, Output{
+			Name:      name,
+			Sensitive: o.Sensitive,
+			Type:      t,
+			value:     o.Value,
+		})
+	}
+	return outputs, nil
+}